@@ -0,0 +1,73 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// CallerName returns the name of the function skip frames above the caller,
+// with its package path stripped, or "???" if it cannot be determined.
+func CallerName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return string(dunno)
+	}
+	return string(function(pc))
+}
+
+// ParentFuncSignature returns the name of the function that called the
+// caller of ParentFuncSignature, together with the textual argument list
+// found at that call site, e.g. `TestFoo("bar", 42)`. As with CallerName,
+// the package path is stripped from the name.
+func ParentFuncSignature() string {
+	callerPC, _, _, callerOK := runtime.Caller(1)
+	parentPC, file, line, parentOK := runtime.Caller(2)
+	if !parentOK {
+		return string(dunno)
+	}
+
+	name := function(parentPC)
+	var args []byte
+	if callerOK {
+		if lines, ok := readSource(file); ok {
+			args = callArgs(source(lines, line-1), function(callerPC))
+		}
+	}
+	return string(name) + "(" + string(args) + ")"
+}
+
+// callArgs extracts the parenthesised argument list of the call to fn found
+// in src, or nil if it can't be located.
+func callArgs(src, fn []byte) []byte {
+	name := fn
+	if i := bytes.LastIndex(name, dot); i >= 0 {
+		name = name[i+1:]
+	}
+	i := bytes.Index(src, name)
+	if i < 0 {
+		return nil
+	}
+	rest := src[i+len(name):]
+	open := bytes.IndexByte(rest, '(')
+	if open < 0 {
+		return nil
+	}
+	rest = rest[open:]
+	depth := 0
+	for i, b := range rest {
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return rest[1:i]
+			}
+		}
+	}
+	return nil
+}
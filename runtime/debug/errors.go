@@ -0,0 +1,60 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import "fmt"
+
+// StackError is implemented by errors that carry the stack trace of the
+// site at which they were created.
+type StackError interface {
+	error
+
+	// StackTrace returns the stack trace captured when the error was
+	// created, in the same format as Stack.
+	StackTrace() string
+}
+
+// stackError is the StackError implementation returned by Errorf and
+// WithStack.
+type stackError struct {
+	err   error
+	stack string
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+
+func (e *stackError) StackTrace() string { return e.stack }
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// Errorf formats according to a format specifier and returns the resulting
+// error, as fmt.Errorf does, but additionally attaches the stack trace of
+// the caller, retrievable via the returned error's StackTrace method.
+//
+// If any of args already carries a stack trace, that trace is reused
+// instead of capturing a new one.
+func Errorf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	for _, a := range args {
+		if se, ok := a.(StackError); ok {
+			return &stackError{err: err, stack: se.StackTrace()}
+		}
+	}
+	return &stackError{err: err, stack: string(stack(0, true))}
+}
+
+// WithStack annotates err with the stack trace of the caller, retrievable
+// via the returned error's StackTrace method. It returns nil if err is nil.
+//
+// If err already implements StackError, its trace is reused.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	if se, ok := err.(StackError); ok {
+		return &stackError{err: err, stack: se.StackTrace()}
+	}
+	return &stackError{err: err, stack: string(stack(0, true))}
+}
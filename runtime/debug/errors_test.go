@@ -0,0 +1,69 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorfCapturesStack(t *testing.T) {
+	err := Errorf("boom: %d", 42)
+	if got := err.Error(); got != "boom: 42" {
+		t.Fatalf("Errorf().Error() = %q, want %q", got, "boom: 42")
+	}
+	se, ok := err.(StackError)
+	if !ok {
+		t.Fatalf("Errorf() = %T, want a StackError", err)
+	}
+	if !strings.Contains(se.StackTrace(), "TestErrorfCapturesStack") {
+		t.Fatalf("StackTrace() = %q, want it to mention TestErrorfCapturesStack", se.StackTrace())
+	}
+}
+
+func TestErrorfReusesExistingStack(t *testing.T) {
+	inner := Errorf("inner").(StackError)
+	outer := Errorf("outer: %w", inner).(StackError)
+	if outer.StackTrace() != inner.StackTrace() {
+		t.Fatalf("Errorf() with a StackError arg captured a new trace instead of reusing the existing one")
+	}
+}
+
+func TestWithStackNil(t *testing.T) {
+	if got := WithStack(nil); got != nil {
+		t.Fatalf("WithStack(nil) = %v, want nil", got)
+	}
+}
+
+func TestWithStackCapturesStack(t *testing.T) {
+	err := WithStack(errors.New("boom"))
+	se, ok := err.(StackError)
+	if !ok {
+		t.Fatalf("WithStack() = %T, want a StackError", err)
+	}
+	if !strings.Contains(se.StackTrace(), "TestWithStackCapturesStack") {
+		t.Fatalf("StackTrace() = %q, want it to mention TestWithStackCapturesStack", se.StackTrace())
+	}
+}
+
+func TestWithStackReusesExistingStack(t *testing.T) {
+	inner := Errorf("inner").(StackError)
+	outer := WithStack(inner).(StackError)
+	if outer.StackTrace() != inner.StackTrace() {
+		t.Fatalf("WithStack() on a StackError captured a new trace instead of reusing the existing one")
+	}
+}
+
+func TestStackErrorUnwrap(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := WithStack(sentinel)
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("errors.Is(WithStack(sentinel), sentinel) = false, want true")
+	}
+	if got := errors.Unwrap(err); got != sentinel {
+		t.Fatalf("errors.Unwrap(WithStack(sentinel)) = %v, want %v", got, sentinel)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONOptions controls the output of StackJSONOptions.
+type JSONOptions struct {
+	// OmitSource suppresses the source line (and any Context) from each
+	// frame, for environments where source files aren't shipped alongside
+	// the binary.
+	OmitSource bool
+
+	// Context is the number of source lines to include before and after
+	// each frame's own line, for extra surrounding context. Zero, the
+	// default, includes only the frame's own line.
+	Context int
+}
+
+// jsonFrame is the wire representation of a Frame.
+type jsonFrame struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	PC       uintptr  `json:"pc"`
+	Function string   `json:"function"`
+	Source   string   `json:"source,omitempty"`
+	Context  []string `json:"context,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding f as an object with
+// "file", "line", "pc", "function" and, unless empty, "source" and
+// "context" fields.
+func (f Frame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonFrame{
+		File:     f.File,
+		Line:     f.Line,
+		PC:       f.PC,
+		Function: f.Function,
+		Source:   f.Source,
+		Context:  f.context,
+	})
+}
+
+// StackJSON returns the stack trace of the goroutine that calls it,
+// marshaled as a JSON array of frame objects, using the default options.
+func StackJSON() []byte {
+	return stackJSON(JSONOptions{})
+}
+
+// StackJSONOptions is like StackJSON but accepts JSONOptions to control the
+// output.
+func StackJSONOptions(opts JSONOptions) []byte {
+	return stackJSON(opts)
+}
+
+func stackJSON(opts JSONOptions) []byte {
+	fr := frames(2, !opts.OmitSource)
+	if !opts.OmitSource && opts.Context > 0 {
+		for i := range fr {
+			fr[i].context = sourceContext(fr[i].File, fr[i].Line, opts.Context)
+		}
+	}
+	data, err := json.Marshal(fr)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// sourceContext returns up to n source lines before and after the given
+// line (1-indexed) in file, or nil if file can't be read.
+func sourceContext(file string, line, n int) []string {
+	lines, ok := readSource(file)
+	if !ok {
+		return nil
+	}
+	start := line - 1 - n
+	if start < 0 {
+		start = 0
+	}
+	end := line + n
+	if end > len(lines) {
+		end = len(lines)
+	}
+	ctx := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		ctx = append(ctx, string(bytes.TrimRight(lines[i], "\r")))
+	}
+	return ctx
+}
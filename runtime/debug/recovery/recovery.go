@@ -0,0 +1,113 @@
+// Package recovery provides an HTTP middleware that recovers panics raised
+// by downstream handlers, logging the panic value together with a stack
+// trace, and responds with an HTTP 500 instead of letting the panic reach
+// net/http and close the connection.
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+
+	"github.com/dnwe/misc/runtime/debug"
+)
+
+// Recoverer is an HTTP middleware that recovers panics raised by the
+// handlers it wraps. The zero value is ready to use and logs to os.Stderr.
+type Recoverer struct {
+	// Writer is where the panic value and stack trace are logged. If nil,
+	// os.Stderr is used. Ignored when Handle is set.
+	Writer io.Writer
+
+	// DumpRequest includes a dump of the failing request, obtained via
+	// httputil.DumpRequest, alongside the logged stack trace. Ignored when
+	// Handle is set.
+	DumpRequest bool
+
+	// Handle, if set, is called with the recovered panic value and the
+	// captured stack trace instead of the default logging and 500
+	// response, letting callers render their own error page or emit a
+	// structured log event.
+	Handle func(w http.ResponseWriter, r *http.Request, err interface{}, stack []byte)
+}
+
+// Handler wraps next with panic recovery using the zero value Recoverer.
+func Handler(next http.Handler) http.Handler {
+	return new(Recoverer).Wrap(next)
+}
+
+// HandlerFunc is like Handler but takes an http.HandlerFunc.
+func HandlerFunc(next http.HandlerFunc) http.Handler {
+	return Handler(next)
+}
+
+// Wrap returns next wrapped with rc's panic recovery behaviour.
+func (rc *Recoverer) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer rc.recover(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rc *Recoverer) recover(w http.ResponseWriter, r *http.Request) {
+	err := recover()
+	if err == nil {
+		return
+	}
+	if err == http.ErrAbortHandler {
+		// ErrAbortHandler is the documented sentinel for deliberately
+		// aborting a response (e.g. a reverse proxy abandoning a stream);
+		// net/http expects it to keep propagating, not to be handled here.
+		panic(err)
+	}
+	if isBrokenPipe(err) {
+		// The client went away mid-request; there's no one left to answer
+		// and logging it would just spam the logs on routine disconnects.
+		return
+	}
+
+	stack := debug.StackSkip(2) // skip this deferred func and the runtime panic machinery
+
+	if rc.Handle != nil {
+		rc.Handle(w, r, err, stack)
+		return
+	}
+
+	out := rc.Writer
+	if out == nil {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "panic: %v\n%s\n", err, stack)
+	if rc.DumpRequest {
+		if dump, dumpErr := httputil.DumpRequest(r, false); dumpErr == nil {
+			fmt.Fprintf(out, "request:\n%s\n", dump)
+		}
+	}
+
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// isBrokenPipe reports whether err, as recovered from a panic, is a network
+// error caused by the client disconnecting, such as a broken pipe or a
+// connection reset.
+func isBrokenPipe(err interface{}) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
+	}
+	var ne *net.OpError
+	if !errors.As(e, &ne) {
+		return false
+	}
+	var se *os.SyscallError
+	if !errors.As(ne, &se) {
+		return false
+	}
+	msg := strings.ToLower(se.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,119 @@
+package recovery
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHandlerRecoversAndWrites500(t *testing.T) {
+	var buf bytes.Buffer
+	rc := &Recoverer{Writer: &buf}
+
+	h := rc.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(buf.String(), "panic: boom") {
+		t.Fatalf("logged output = %q, want it to mention the panic value", buf.String())
+	}
+}
+
+func TestHandlerDumpRequest(t *testing.T) {
+	var buf bytes.Buffer
+	rc := &Recoverer{Writer: &buf, DumpRequest: true}
+
+	h := rc.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/widgets", nil))
+
+	if !strings.Contains(buf.String(), "/widgets") {
+		t.Fatalf("logged output = %q, want a dump of the request", buf.String())
+	}
+}
+
+func TestHandlerCustomHandle(t *testing.T) {
+	var gotErr interface{}
+	var gotStack []byte
+	rc := &Recoverer{
+		Handle: func(w http.ResponseWriter, r *http.Request, err interface{}, stack []byte) {
+			gotErr, gotStack = err, stack
+			w.WriteHeader(http.StatusTeapot)
+		},
+	}
+
+	h := rc.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTeapot)
+	}
+	if gotErr != "boom" {
+		t.Fatalf("Handle received err = %v, want %q", gotErr, "boom")
+	}
+	if len(gotStack) == 0 {
+		t.Fatal("Handle received an empty stack trace")
+	}
+}
+
+func TestHandlerSuppressesBrokenPipe(t *testing.T) {
+	var buf bytes.Buffer
+	rc := &Recoverer{Writer: &buf}
+
+	brokenPipe := &net.OpError{
+		Op:  "write",
+		Err: &os.SyscallError{Syscall: "write", Err: brokenPipeErr{}},
+	}
+
+	h := rc.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(brokenPipe)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want the default %d (no response written)", w.Code, http.StatusOK)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("logged output = %q, want broken-pipe panics to be suppressed", buf.String())
+	}
+}
+
+func TestHandlerRepanicsErrAbortHandler(t *testing.T) {
+	rc := &Recoverer{Writer: &bytes.Buffer{}}
+
+	h := rc.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if got := recover(); got != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler to propagate", got)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	t.Fatal("expected http.ErrAbortHandler to propagate out of ServeHTTP")
+}
+
+// brokenPipeErr implements error with the message isBrokenPipe matches on.
+type brokenPipeErr struct{}
+
+func (brokenPipeErr) Error() string { return "broken pipe" }
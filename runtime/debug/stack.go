@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"os"
 	"runtime"
+	"sync"
 )
 
 var (
@@ -22,7 +23,13 @@ var (
 
 // PrintStack prints to standard error the stack trace returned by Stack.
 func PrintStack() {
-	os.Stderr.Write(stack())
+	os.Stderr.Write(stack(0, true))
+}
+
+// PrintStackSkip is like PrintStack but skips skip additional frames above
+// the caller.
+func PrintStackSkip(skip int) {
+	os.Stderr.Write(stack(skip, true))
 }
 
 // Stack returns a formatted stack trace of the goroutine that calls it.
@@ -30,37 +37,129 @@ func PrintStack() {
 // then attempts to discover, for Go functions, the calling function or
 // method and the text of the line containing the invocation.
 func Stack() []byte {
-	return stack()
+	return stack(0, true)
+}
+
+// StackSkip is like Stack but skips skip additional frames above the
+// caller.
+func StackSkip(skip int) []byte {
+	return stack(skip, true)
+}
+
+// StackSkipFast is like StackSkip but never reads source files, producing a
+// trace from runtime symbol information alone.
+func StackSkipFast(skip int) []byte {
+	return stack(skip, false)
+}
+
+// Frame describes a single entry in a stack trace.
+type Frame struct {
+	PC       uintptr
+	File     string
+	Line     int
+	Function string
+	Source   string
+
+	// context holds additional source lines surrounding Source, set by
+	// StackJSONOptions. Empty otherwise.
+	context []string
 }
 
-// stack implements Stack, skipping 2 frames
-func stack() []byte {
-	buf := new(bytes.Buffer) // the returned data
-	// As we loop, we open files and read them. These variables record the currently
-	// loaded file.
+// Frames returns the structured stack trace of the goroutine that calls it,
+// built on runtime.CallersFrames.
+func Frames() []Frame {
+	return frames(1, true)
+}
+
+// frames is the shared frame-walking implementation behind Stack, StackSkip
+// and Frames, skipping skip frames above its own caller. When withSource is
+// false, source files are never read.
+func frames(skip int, withSource bool) []Frame {
+	// runtime.Callers' skip counts frame 0 as Callers itself and frame 1 as
+	// frames, so add 2 here so that a skip of 0 starts at frames' own caller.
+	pcs := make([]uintptr, 32)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+
+	var result []Frame
 	var lines [][]byte
 	var lastFile string
-	for i := 2; ; i++ { // Caller we care about is the user, 2 frames up
-		pc, file, line, ok := runtime.Caller(i)
-		if !ok {
-			break
+	var lastOK bool
+	callerFrames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callerFrames.Next()
+		fr := Frame{
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: string(cleanName([]byte(frame.Function))),
 		}
-		// Print this much at least.  If we can't find the source, it won't show.
-		fmt.Fprintf(buf, "%s:%d (0x%x)\n", file, line, pc)
-		if file != lastFile {
-			data, err := ioutil.ReadFile(file)
-			if err != nil {
-				continue
+		if withSource {
+			if frame.File != lastFile {
+				lines, lastOK = readSource(frame.File)
+				lastFile = frame.File
 			}
-			lines = bytes.Split(data, []byte{'\n'})
-			lastFile = file
+			if lastOK {
+				fr.Source = string(source(lines, frame.Line-1))
+			}
+		}
+		result = append(result, fr)
+		if !more {
+			break
 		}
-		line-- // in stack trace, lines are 1-indexed but our array is 0-indexed
-		fmt.Fprintf(buf, "\t%s: %s\n", function(pc), source(lines, line))
+	}
+	return result
+}
+
+// stack implements Stack, StackSkip and StackSkipFast on top of frames,
+// reproducing the original text format.
+func stack(skip int, withSource bool) []byte {
+	buf := new(bytes.Buffer)
+	for _, f := range frames(skip+2, withSource) {
+		fmt.Fprintf(buf, "%s:%d (0x%x)\n", f.File, f.Line, f.PC)
+		src := f.Source
+		if src == "" {
+			src = "source unavailable"
+		}
+		fmt.Fprintf(buf, "\t%s: %s\n", f.Function, src)
 	}
 	return buf.Bytes()
 }
 
+var (
+	sourceCacheMu sync.Mutex
+	sourceCache   = map[string][][]byte{}
+)
+
+// readSource returns the lines of file, split on '\n', caching the result
+// (including failures) process-wide. The second result reports whether file
+// could be read at all.
+func readSource(file string) ([][]byte, bool) {
+	sourceCacheMu.Lock()
+	lines, cached := sourceCache[file]
+	sourceCacheMu.Unlock()
+	if cached {
+		return lines, lines != nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	var result [][]byte
+	if err == nil {
+		result = bytes.Split(data, []byte{'\n'})
+	}
+
+	sourceCacheMu.Lock()
+	sourceCache[file] = result
+	sourceCacheMu.Unlock()
+	return result, result != nil
+}
+
 // source returns a space-trimmed slice of the n'th line.
 func source(lines [][]byte, n int) []byte {
 	if n < 0 || n >= len(lines) {
@@ -75,18 +174,27 @@ func function(pc uintptr) []byte {
 	if fn == nil {
 		return dunno
 	}
-	name := []byte(fn.Name())
-	// The name includes the path name to the package, which is unnecessary
-	// since the file name is already included.  Plus, it has center dots.
-	// That is, we see
-	//	runtime/debug.*T·ptrmethod
-	// and want
-	//	*T.ptrmethod
-	if period := bytes.Index(name, dot); period >= 0 {
-		name = name[period+1:]
+	return cleanName([]byte(fn.Name()))
+}
+
+// cleanName strips the package path from a raw function name such as
+// "github.com/dnwe/misc/runtime/debug.*T·ptrmethod" and normalizes center
+// dots, leaving "*T.ptrmethod". The package path is unnecessary since the
+// file name is already included elsewhere.
+//
+// The package/function boundary is the first dot after the last slash:
+// import paths are frequently dotted themselves (e.g. a host component like
+// "github.com"), so splitting on the first dot anywhere in name would cut
+// into the path instead of at the function name.
+func cleanName(name []byte) []byte {
+	pkg := name
+	if slash := bytes.LastIndexByte(name, '/'); slash >= 0 {
+		pkg = name[slash+1:]
+	}
+	if period := bytes.IndexByte(pkg, '.'); period >= 0 {
+		name = pkg[period+1:]
 	}
-	name = bytes.Replace(name, centerDot, dot, -1)
-	return name
+	return bytes.Replace(name, centerDot, dot, -1)
 }
 
 // Callers returns the stack trace of the goroutine that called it,
@@ -0,0 +1,122 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// frameFunc returns the function name recorded against the first frame of a
+// Stack/StackSkip-formatted trace.
+func frameFunc(t *testing.T, b []byte) string {
+	t.Helper()
+	lines := bytes.SplitN(b, []byte{'\n'}, 3)
+	if len(lines) < 2 {
+		t.Fatalf("unexpected trace: %s", b)
+	}
+	fn, _, ok := bytesCut(bytes.TrimPrefix(lines[1], []byte{'\t'}), ':')
+	if !ok {
+		t.Fatalf("unexpected frame line: %s", lines[1])
+	}
+	return string(fn)
+}
+
+func bytesCut(s []byte, sep byte) ([]byte, []byte, bool) {
+	if i := bytes.IndexByte(s, sep); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, nil, false
+}
+
+func viaStackSkip() []byte {
+	return StackSkip(1) // hide this wrapper's own frame
+}
+
+func TestStackSkip(t *testing.T) {
+	direct := frameFunc(t, Stack())
+	wrapped := frameFunc(t, viaStackSkip())
+	if direct != wrapped || !strings.HasSuffix(direct, "TestStackSkip") {
+		t.Fatalf("Stack() reported %q, viaStackSkip() reported %q, want both to be TestStackSkip", direct, wrapped)
+	}
+}
+
+func TestFrames(t *testing.T) {
+	fr := Frames()
+	if len(fr) == 0 {
+		t.Fatal("Frames() returned no frames")
+	}
+	if !strings.HasSuffix(fr[0].Function, "TestFrames") {
+		t.Fatalf("Frames()[0].Function = %q, want suffix TestFrames", fr[0].Function)
+	}
+	if fr[0].Source == "" {
+		t.Fatal("Frames()[0].Source is empty, want the calling line")
+	}
+}
+
+func TestStackSkipFast(t *testing.T) {
+	b := StackSkipFast(0)
+	if !bytes.Contains(b, []byte("source unavailable")) {
+		t.Fatalf("StackSkipFast() = %s, want frames marked source unavailable", b)
+	}
+}
+
+func TestStackJSON(t *testing.T) {
+	var frames []map[string]interface{}
+	if err := json.Unmarshal(StackJSON(), &frames); err != nil {
+		t.Fatalf("unmarshaling StackJSON(): %v", err)
+	}
+	if len(frames) == 0 {
+		t.Fatal("StackJSON() produced no frames")
+	}
+	if _, ok := frames[0]["source"]; !ok {
+		t.Fatal(`StackJSON()[0] missing "source" by default`)
+	}
+
+	var omitted []map[string]interface{}
+	if err := json.Unmarshal(StackJSONOptions(JSONOptions{OmitSource: true}), &omitted); err != nil {
+		t.Fatalf("unmarshaling StackJSONOptions: %v", err)
+	}
+	if _, ok := omitted[0]["source"]; ok {
+		t.Fatal(`StackJSONOptions(OmitSource: true)[0] still has "source"`)
+	}
+}
+
+func reportCallerName() string {
+	return CallerName(0)
+}
+
+func reportGrandcallerName() string {
+	return CallerName(1)
+}
+
+func TestCallerName(t *testing.T) {
+	// This package's own import path, github.com/dnwe/misc/runtime/debug,
+	// has a dot in its host component: exact equality (not just a suffix
+	// match) guards against splitting on the first dot anywhere in the raw
+	// symbol name rather than at the package/function boundary.
+	if got := reportCallerName(); got != "reportCallerName" {
+		t.Fatalf("CallerName(0) = %q, want %q", got, "reportCallerName")
+	}
+	if got := reportGrandcallerName(); got != "TestCallerName" {
+		t.Fatalf("CallerName(1) = %q, want %q", got, "TestCallerName")
+	}
+}
+
+func reportParentSignature(a int, b string) string {
+	return ParentFuncSignature()
+}
+
+func TestParentFuncSignature(t *testing.T) {
+	got := reportParentSignature(42, "x")
+	if !strings.Contains(got, "TestParentFuncSignature(") {
+		t.Fatalf("ParentFuncSignature() = %q, want it to name TestParentFuncSignature", got)
+	}
+	if !strings.Contains(got, `42, "x"`) {
+		t.Fatalf("ParentFuncSignature() = %q, want it to include the call's argument list", got)
+	}
+}